@@ -0,0 +1,80 @@
+// Package httpmetrics provides an HTTP middleware chain that logs each
+// request as structured JSON and records Prometheus metrics labeled by route
+// and Cloudflare region, so traffic can be aggregated across the many
+// short-lived container instances Cloudflare Containers spins up per region.
+package httpmetrics
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests handled, labeled by route, method, status, and CF region.",
+	}, []string{"route", "method", "status", "region"})
+
+	requestsInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests currently being handled, labeled by route.",
+	}, []string{"route"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of HTTP requests, labeled by route and CF region.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "region"})
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+// statusRecorder wraps http.ResponseWriter to capture the status code written
+// by the handler, which net/http does not expose otherwise.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Wrap instruments handler as route: it emits a JSON access log line and
+// records request totals, in-flight gauge, and latency histogram for every
+// call, labeled with the Cloudflare region/colo the instance is running in.
+func Wrap(route string, handler http.HandlerFunc) http.HandlerFunc {
+	// CLOUDFLARE_LOCATION is the colo and CLOUDFLARE_REGION is the region, the
+	// same split internal/meta.Info uses for Location/Region.
+	colo := os.Getenv("CLOUDFLARE_LOCATION")
+	region := os.Getenv("CLOUDFLARE_REGION")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		requestsInFlight.WithLabelValues(route).Inc()
+		defer requestsInFlight.WithLabelValues(route).Dec()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(rec, r)
+
+		duration := time.Since(start)
+		requestsTotal.WithLabelValues(route, r.Method, strconv.Itoa(rec.status), region).Inc()
+		requestDuration.WithLabelValues(route, region).Observe(duration.Seconds())
+
+		logger.Info("request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration_ms", duration.Milliseconds(),
+			"cf_colo", colo,
+			"cf_region", region,
+		)
+	}
+}
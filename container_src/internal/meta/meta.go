@@ -0,0 +1,60 @@
+// Package meta collects machine-readable information about the running
+// container instance so it can be reused across HTTP handlers instead of
+// being built ad hoc as a formatted string.
+package meta
+
+import (
+	"net/http"
+	"os"
+	"runtime"
+	"time"
+)
+
+// Info describes a single container instance at the moment it is collected.
+type Info struct {
+	Location  string `json:"location"`
+	Country   string `json:"country"`
+	Region    string `json:"region"`
+	Hostname  string `json:"hostname"`
+	GoVersion string `json:"go_version"`
+	NumCPU    int    `json:"num_cpu"`
+	UptimeSec int64  `json:"uptime_seconds"`
+	TTLSec    int64  `json:"ttl_remaining_seconds"`
+
+	// Request describes the incoming request, when Info is collected for a
+	// specific *http.Request. It is omitted for process-wide snapshots.
+	Request *RequestInfo `json:"request,omitempty"`
+}
+
+// RequestInfo holds the Cloudflare-specific headers attached to a single
+// request.
+type RequestInfo struct {
+	ConnectingIP string `json:"cf_connecting_ip"`
+	IPCountry    string `json:"cf_ip_country"`
+	Ray          string `json:"cf_ray"`
+}
+
+// Collect builds process-wide Info: Cloudflare placement env vars, runtime
+// facts, and the given started/ttlRemaining values.
+func Collect(started time.Time, ttlRemaining int64) Info {
+	hostname, _ := os.Hostname()
+	return Info{
+		Location:  os.Getenv("CLOUDFLARE_LOCATION"),
+		Country:   os.Getenv("CLOUDFLARE_COUNTRY_A2"),
+		Region:    os.Getenv("CLOUDFLARE_REGION"),
+		Hostname:  hostname,
+		GoVersion: runtime.Version(),
+		NumCPU:    runtime.NumCPU(),
+		UptimeSec: int64(time.Since(started).Seconds()),
+		TTLSec:    ttlRemaining,
+	}
+}
+
+// FromRequest extracts the Cloudflare headers carried on r.
+func FromRequest(r *http.Request) *RequestInfo {
+	return &RequestInfo{
+		ConnectingIP: r.Header.Get("CF-Connecting-IP"),
+		IPCountry:    r.Header.Get("CF-IPCountry"),
+		Ray:          r.Header.Get("CF-Ray"),
+	}
+}
@@ -0,0 +1,164 @@
+// Package peers implements a lightweight gossip-style discovery subsystem so
+// multiple Cloudflare Container instances can find each other for demo
+// scenarios like distributed counters or region-aware routing, without
+// pulling in a full service mesh.
+package peers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Identity is what a container instance announces about itself.
+type Identity struct {
+	Hostname  string    `json:"hostname"`
+	Colo      string    `json:"cf_colo"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// entry is a known peer plus bookkeeping for LRU/TTL eviction.
+type entry struct {
+	Identity
+	LastSeen time.Time
+}
+
+// Registry tracks known peers, evicting the least-recently-seen entries once
+// maxPeers is exceeded and pruning entries that have gone stale past ttl.
+type Registry struct {
+	mu       sync.RWMutex
+	peers    map[string]entry
+	maxPeers int
+	ttl      time.Duration
+}
+
+// NewRegistry creates an empty Registry bounded to maxPeers entries, each
+// considered stale after ttl has passed since it was last seen.
+func NewRegistry(maxPeers int, ttl time.Duration) *Registry {
+	return &Registry{
+		peers:    make(map[string]entry),
+		maxPeers: maxPeers,
+		ttl:      ttl,
+	}
+}
+
+// Upsert records id as seen now, evicting the oldest entry first if the
+// registry is full and id is not already known.
+func (r *Registry) Upsert(id Identity) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.peers[id.Hostname]; !ok && len(r.peers) >= r.maxPeers {
+		r.evictOldestLocked()
+	}
+	r.peers[id.Hostname] = entry{Identity: id, LastSeen: time.Now()}
+}
+
+// evictOldestLocked removes the least-recently-seen peer. Callers must hold
+// r.mu for writing.
+func (r *Registry) evictOldestLocked() {
+	var oldestKey string
+	var oldestSeen time.Time
+	for k, v := range r.peers {
+		if oldestKey == "" || v.LastSeen.Before(oldestSeen) {
+			oldestKey = k
+			oldestSeen = v.LastSeen
+		}
+	}
+	if oldestKey != "" {
+		delete(r.peers, oldestKey)
+	}
+}
+
+// Prune removes peers that have not been seen within the registry's ttl.
+func (r *Registry) Prune() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cutoff := time.Now().Add(-r.ttl)
+	for k, v := range r.peers {
+		if v.LastSeen.Before(cutoff) {
+			delete(r.peers, k)
+		}
+	}
+}
+
+// PruneLoop calls Prune on interval until ctx is done. It runs independently
+// of Gossiper so an instance that only receives gossip (no PEERS of its own)
+// still expires stale peers by TTL instead of relying solely on the maxPeers
+// LRU cap.
+func (r *Registry) PruneLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Prune()
+		}
+	}
+}
+
+// Snapshot returns the currently known peers.
+func (r *Registry) Snapshot() []Identity {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]Identity, 0, len(r.peers))
+	for _, v := range r.peers {
+		out = append(out, v.Identity)
+	}
+	return out
+}
+
+// Gossiper periodically announces self to a set of seed peers. It does not
+// prune Registry; stale entries are removed by Registry.PruneLoop.
+type Gossiper struct {
+	Self     Identity
+	Seeds    []string
+	Registry *Registry
+	Interval time.Duration
+	Client   *http.Client
+}
+
+// Run announces self to every seed, then re-announces on Interval until ctx
+// is done. Pruning stale peers is Registry's own responsibility (see
+// Registry.PruneLoop), since a seed-only instance never runs a Gossiper.
+func (g *Gossiper) Run(ctx context.Context) {
+	client := g.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	g.gossipOnce(client)
+
+	ticker := time.NewTicker(g.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.gossipOnce(client)
+		}
+	}
+}
+
+// gossipOnce POSTs self's identity to every configured seed.
+func (g *Gossiper) gossipOnce(client *http.Client) {
+	body, err := json.Marshal(g.Self)
+	if err != nil {
+		return
+	}
+	for _, seed := range g.Seeds {
+		resp, err := client.Post(seed, "application/json", bytes.NewReader(body))
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+	}
+}
@@ -0,0 +1,69 @@
+package peers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegistryUpsertEvictsLeastRecentlySeen(t *testing.T) {
+	r := NewRegistry(2, time.Hour)
+
+	r.Upsert(Identity{Hostname: "a"})
+	r.Upsert(Identity{Hostname: "b"})
+	r.Upsert(Identity{Hostname: "c"}) // should evict "a", the oldest
+
+	got := map[string]bool{}
+	for _, id := range r.Snapshot() {
+		got[id.Hostname] = true
+	}
+	if got["a"] {
+		t.Errorf("Snapshot() still contains evicted peer %q", "a")
+	}
+	if !got["b"] || !got["c"] {
+		t.Errorf("Snapshot() = %v, want b and c present", got)
+	}
+	if len(got) != 2 {
+		t.Errorf("Snapshot() has %d peers, want 2", len(got))
+	}
+}
+
+func TestRegistryUpsertRefreshesExistingPeer(t *testing.T) {
+	r := NewRegistry(2, time.Hour)
+
+	r.Upsert(Identity{Hostname: "a"})
+	r.Upsert(Identity{Hostname: "b"})
+	r.Upsert(Identity{Hostname: "a"}) // re-seen, should not be evicted by "c" below
+	r.Upsert(Identity{Hostname: "c"}) // should evict "b", now the oldest
+
+	got := map[string]bool{}
+	for _, id := range r.Snapshot() {
+		got[id.Hostname] = true
+	}
+	if got["b"] {
+		t.Errorf("Snapshot() still contains evicted peer %q", "b")
+	}
+	if !got["a"] || !got["c"] {
+		t.Errorf("Snapshot() = %v, want a and c present", got)
+	}
+}
+
+func TestRegistryPruneRemovesStalePeers(t *testing.T) {
+	r := NewRegistry(10, 10*time.Millisecond)
+
+	r.Upsert(Identity{Hostname: "stale"})
+	time.Sleep(20 * time.Millisecond)
+	r.Upsert(Identity{Hostname: "fresh"})
+
+	r.Prune()
+
+	got := map[string]bool{}
+	for _, id := range r.Snapshot() {
+		got[id.Hostname] = true
+	}
+	if got["stale"] {
+		t.Errorf("Snapshot() still contains stale peer after Prune()")
+	}
+	if !got["fresh"] {
+		t.Errorf("Snapshot() missing fresh peer after Prune()")
+	}
+}
@@ -0,0 +1,95 @@
+// Package routeconfig loads a YAML file of synthetic routes so the demo
+// container can be reused as a configurable stand-in for testing Cloudflare
+// Containers routing, without recompiling for every response shape.
+package routeconfig
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// reservedPaths are the built-in routes main.go always registers; a
+// configured route cannot reuse one without panicking the mux at startup.
+var reservedPaths = map[string]bool{
+	"/":        true,
+	"/_health": true,
+	"/info":    true,
+	"/peers":   true,
+	"/metrics": true,
+}
+
+// Route describes a single synthetic HTTP route.
+type Route struct {
+	Path    string            `yaml:"path"`
+	Status  int               `yaml:"status"`
+	Body    string            `yaml:"body"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// Config is the top-level shape of the --config YAML file.
+type Config struct {
+	Routes []Route `yaml:"routes"`
+}
+
+// Load reads and parses the YAML file at path.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config %q: %w", path, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing config %q: %w", path, err)
+	}
+	seenPaths := make(map[string]bool, len(cfg.Routes))
+	for i, route := range cfg.Routes {
+		if route.Path == "" {
+			return nil, fmt.Errorf("config %q: route %d is missing a path", path, i)
+		}
+		if reservedPaths[route.Path] {
+			return nil, fmt.Errorf("config %q: route %d declares reserved path %q, which is already registered by the server", path, i, route.Path)
+		}
+		if seenPaths[route.Path] {
+			return nil, fmt.Errorf("config %q: route %d declares path %q more than once", path, i, route.Path)
+		}
+		seenPaths[route.Path] = true
+		if route.Status == 0 {
+			cfg.Routes[i].Status = 200
+		}
+	}
+	return &cfg, nil
+}
+
+// Render expands {{.CLOUDFLARE_*}}-style template variables in body against
+// the current environment, e.g. "hello from {{.CLOUDFLARE_LOCATION}}".
+func (r Route) Render() (string, error) {
+	tmpl, err := template.New(r.Path).Parse(r.Body)
+	if err != nil {
+		return "", fmt.Errorf("parsing template for route %q: %w", r.Path, err)
+	}
+
+	const envPrefix = "CLOUDFLARE_"
+	vars := map[string]string{}
+	for _, env := range os.Environ() {
+		for i := 0; i < len(env); i++ {
+			if env[i] == '=' {
+				if key := env[:i]; strings.HasPrefix(key, envPrefix) {
+					vars[key] = env[i+1:]
+				}
+				break
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("rendering template for route %q: %w", r.Path, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,88 @@
+package routeconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, yaml string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatalf("writing test config: %v", err)
+	}
+	return path
+}
+
+func TestLoadValidatesRoutes(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name: "missing path",
+			yaml: `routes:
+  - status: 200
+    body: hello
+`,
+			wantErr: "missing a path",
+		},
+		{
+			name: "reserved path",
+			yaml: `routes:
+  - path: /info
+    body: hello
+`,
+			wantErr: "reserved path",
+		},
+		{
+			name: "duplicate path",
+			yaml: `routes:
+  - path: /a
+    body: one
+  - path: /a
+    body: two
+`,
+			wantErr: "more than once",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadDefaultsStatus(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `routes:
+  - path: /a
+    body: hello
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Routes[0].Status; got != 200 {
+		t.Errorf("default Status = %d, want 200", got)
+	}
+}
+
+func TestRouteRenderFiltersToCloudflareVars(t *testing.T) {
+	t.Setenv("CLOUDFLARE_LOCATION", "ord01")
+	t.Setenv("PEERS", "http://leaked-peer")
+
+	route := Route{Path: "/a", Body: "from {{.CLOUDFLARE_LOCATION}}, peers={{.PEERS}}"}
+	got, err := route.Render()
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "from ord01, peers=<no value>"; got != want {
+		t.Errorf("Render() = %q, want %q (non-CLOUDFLARE_ vars must not be exposed)", got, want)
+	}
+}
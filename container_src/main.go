@@ -1,15 +1,60 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/kale-stew/compute-example/container_src/internal/httpmetrics"
+	"github.com/kale-stew/compute-example/container_src/internal/meta"
+	"github.com/kale-stew/compute-example/container_src/internal/peers"
+	"github.com/kale-stew/compute-example/container_src/internal/routeconfig"
+)
+
+const (
+	peersMaxPeers       = 64
+	peersTTL            = 2 * time.Minute
+	peersGossipInterval = 30 * time.Second
 )
 
-var ttl = 0
+var peerRegistry = peers.NewRegistry(peersMaxPeers, peersTTL)
+
+var startedAt = time.Now()
+
+const defaultShutdownTTL = 120 * time.Second
+
+// remainingTTL tracks the number of seconds left before the container shuts
+// itself down, stored atomically since it is read from request handlers and
+// written from the shutdown timer goroutine concurrently.
+var remainingTTL int64
+
+func shutdownTTL(ttlFlag time.Duration) time.Duration {
+	if ttlFlag > 0 {
+		return ttlFlag
+	}
+	if v := os.Getenv("SHUTDOWN_TTL"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultShutdownTTL
+}
 
 func handler(w http.ResponseWriter, r *http.Request) {
 	country := os.Getenv("CLOUDFLARE_COUNTRY_A2")
@@ -27,22 +72,186 @@ func handler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	text += fmt.Sprintf("I have %d cpus \n", runtime.NumCPU())
-	text += fmt.Sprintf("I am shutting down in %d seconds\n", ttl)
+	text += fmt.Sprintf("I am shutting down in %d seconds\n", atomic.LoadInt64(&remainingTTL))
+
+	text += "My known peers are:\n"
+	for _, p := range peerRegistry.Snapshot() {
+		text += fmt.Sprintf("%s (%s)\n", p.Hostname, p.Colo)
+	}
 	fmt.Fprintf(w, text)
 }
 
-func main() {
-	http.HandleFunc("/", handler)
-	http.HandleFunc("/_health", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("ok"))
+// selfIdentity builds this instance's peers.Identity from its hostname, CF
+// colo, and start time.
+func selfIdentity() peers.Identity {
+	hostname, _ := os.Hostname()
+	return peers.Identity{
+		Hostname:  hostname,
+		Colo:      os.Getenv("CLOUDFLARE_LOCATION"),
+		StartedAt: startedAt,
+	}
+}
+
+// peersHandler serves the container's identity and known peer set on GET,
+// and accepts another instance's identity as a gossip announcement on POST.
+func peersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		var id peers.Identity
+		if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		peerRegistry.Upsert(id)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Self  peers.Identity   `json:"self"`
+		Peers []peers.Identity `json:"peers"`
+	}{
+		Self:  selfIdentity(),
+		Peers: peerRegistry.Snapshot(),
 	})
+}
+
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "ok, shutting down in %d seconds\n", atomic.LoadInt64(&remainingTTL))
+}
+
+// infoHandler returns a JSON document describing this container instance,
+// combining process-wide placement/runtime facts with the Cloudflare headers
+// attached to the incoming request.
+func infoHandler(w http.ResponseWriter, r *http.Request) {
+	info := meta.Collect(startedAt, atomic.LoadInt64(&remainingTTL))
+	info.Request = meta.FromRequest(r)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(info); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// runShutdownTimer counts remainingTTL down to zero and then cancels ctx so
+// the caller can begin a graceful server shutdown. It returns early if ctx is
+// already canceled, e.g. by an incoming SIGINT/SIGTERM.
+func runShutdownTimer(ctx context.Context, cancel context.CancelFunc, ttl time.Duration) {
+	atomic.StoreInt64(&remainingTTL, int64(ttl.Seconds()))
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	deadline := time.Now().Add(ttl)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				atomic.StoreInt64(&remainingTTL, 0)
+				cancel()
+				return
+			}
+			atomic.StoreInt64(&remainingTTL, int64(remaining.Round(time.Second).Seconds()))
+		}
+	}
+}
+
+// configRouteHandler builds an http.HandlerFunc that serves the static (but
+// template-rendered) response declared for route in the --config YAML file.
+func configRouteHandler(route routeconfig.Route) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := route.Render()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for k, v := range route.Headers {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(route.Status)
+		fmt.Fprint(w, body)
+	}
+}
+
+func main() {
+	ttlFlag := flag.Duration("ttl", 0, "how long the container should run before shutting itself down (overrides SHUTDOWN_TTL)")
+	configPath := flag.String("config", "", "path to a YAML file declaring synthetic routes to register alongside / and /_health")
+	flag.Parse()
+
+	ttl := shutdownTTL(*ttlFlag)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", httpmetrics.Wrap("/", handler))
+	mux.HandleFunc("/_health", httpmetrics.Wrap("/_health", healthHandler))
+	mux.HandleFunc("/info", httpmetrics.Wrap("/info", infoHandler))
+	mux.HandleFunc("/peers", httpmetrics.Wrap("/peers", peersHandler))
+	mux.Handle("/metrics", promhttp.Handler())
+
+	if *configPath != "" {
+		cfg, err := routeconfig.Load(*configPath)
+		if err != nil {
+			log.Fatalf("loading route config: %v", err)
+		}
+		for _, route := range cfg.Routes {
+			mux.HandleFunc(route.Path, httpmetrics.Wrap(route.Path, configRouteHandler(route)))
+			log.Printf("registered configured route %s -> status %d", route.Path, route.Status)
+		}
+	}
 
+	srv := &http.Server{
+		Addr:    ":8080",
+		Handler: mux,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
-		for i := 0; i < 120; i++ {
-			time.Sleep(time.Second)
-			ttl = 120 - i
+		select {
+		case sig := <-sigCh:
+			log.Printf("received signal %s, shutting down", sig)
+			cancel()
+		case <-ctx.Done():
 		}
-		os.Exit(0)
 	}()
-	log.Fatal(http.ListenAndServe(":8080", nil))
+
+	go runShutdownTimer(ctx, cancel, ttl)
+
+	go peerRegistry.PruneLoop(ctx, peersGossipInterval)
+
+	if seeds := os.Getenv("PEERS"); seeds != "" {
+		gossiper := &peers.Gossiper{
+			Self:     selfIdentity(),
+			Seeds:    strings.Split(seeds, ","),
+			Registry: peerRegistry,
+			Interval: peersGossipInterval,
+		}
+		go gossiper.Run(ctx)
+	}
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer shutdownCancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("error during graceful shutdown: %v", err)
+		}
+	}()
+
+	log.Printf("listening on %s, shutting down in %s", srv.Addr, ttl)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+
+	// Shutdown closed the listener above, but its drain of in-flight
+	// connections runs concurrently in the goroutine; wait for it so we
+	// don't exit out from under a request that's still being served.
+	<-shutdownDone
+	log.Println("server stopped")
 }